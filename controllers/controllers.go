@@ -4,16 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"Key_Value_Cache_Ass/metrics"
 	"Key_Value_Cache_Ass/models"
 )
 
-var CacheInstance = models.NewCache()
+var CacheInstance models.Store = models.NewChainCacheFromEnv()
 
 var (
 	putRequestPool = sync.Pool{
@@ -42,11 +44,15 @@ var (
 	missingKeyResponseBytes = []byte(`{"status":"ERROR","message":"Missing key parameter"}`)
 	tooManyRequestsResponseBytes = []byte(`{"status":"ERROR","message":"Too many requests"}`)
 	timeoutResponseBytes = []byte(`{"status":"ERROR","message":"Request timeout"}`)
+	internalErrorResponseBytes = []byte(`{"status":"ERROR","message":"Internal error"}`)
+	watchNotSupportedResponseBytes = []byte(`{"status":"ERROR","message":"Watch is not supported by the configured cache tier"}`)
+	streamingNotSupportedResponseBytes = []byte(`{"status":"ERROR","message":"Streaming is not supported by this server"}`)
 )
 
 type PutRequest struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
+	TTL   int64  `json:"ttl,omitempty"` // optional time-to-live in seconds; 0 means no expiry
 }
 
 type Response struct {
@@ -91,6 +97,7 @@ func PutCache(w http.ResponseWriter, r *http.Request) {
 	case semaphore <- struct{}{}:
 		defer func() { <-semaphore }()
 	default:
+		metrics.HTTPRejectedTotal.WithLabelValues("too_many_requests").Inc()
 		writeJSONResponse(w, http.StatusTooManyRequests, tooManyRequestsResponseBytes)
 		return
 	}
@@ -132,7 +139,10 @@ func PutCache(w http.ResponseWriter, r *http.Request) {
 		writeJSONResponse(w, http.StatusRequestTimeout, timeoutResponseBytes)
 		return
 	default:
-		CacheInstance.Put(req.Key, req.Value)
+		if err := CacheInstance.Put(ctx, req.Key, req.Value, time.Duration(req.TTL)*time.Second); err != nil {
+			writeJSONResponse(w, http.StatusInternalServerError, internalErrorResponseBytes)
+			return
+		}
 		writeJSONResponse(w, http.StatusOK, successPutResponseBytes)
 	}
 }
@@ -142,6 +152,7 @@ func GetCache(w http.ResponseWriter, r *http.Request) {
 	case semaphore <- struct{}{}:
 		defer func() { <-semaphore }()
 	default:
+		metrics.HTTPRejectedTotal.WithLabelValues("too_many_requests").Inc()
 		writeJSONResponse(w, http.StatusTooManyRequests, tooManyRequestsResponseBytes)
 		return
 	}
@@ -160,7 +171,11 @@ func GetCache(w http.ResponseWriter, r *http.Request) {
 		writeJSONResponse(w, http.StatusRequestTimeout, timeoutResponseBytes)
 		return
 	default:
-		val, found := CacheInstance.Get(key)
+		val, found, err := CacheInstance.Get(ctx, key)
+		if err != nil {
+			writeJSONResponse(w, http.StatusInternalServerError, internalErrorResponseBytes)
+			return
+		}
 		if !found {
 			writeJSONResponse(w, http.StatusNotFound, keyNotFoundResponseBytes)
 			return
@@ -178,3 +193,89 @@ func GetCache(w http.ResponseWriter, r *http.Request) {
 		writeResponse(w, http.StatusOK, resp)
 	}
 }
+
+// WatchCache streams Put/Delete/Evict events for keys matching ?prefix=
+// (empty matches every key) as Server-Sent Events. If ?from_rev=N is given
+// with N > 0, buffered events newer than N are replayed first so a reconnecting client
+// doesn't miss anything that happened while it was away.
+func WatchCache(w http.ResponseWriter, r *http.Request) {
+	watcher, ok := CacheInstance.(models.Watcher)
+	if !ok {
+		writeJSONResponse(w, http.StatusNotImplemented, watchNotSupportedResponseBytes)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONResponse(w, http.StatusNotImplemented, streamingNotSupportedResponseBytes)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+
+	var fromRev uint64
+	if raw := r.URL.Query().Get("from_rev"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, invalidJSONResponseBytes)
+			return
+		}
+		fromRev = parsed
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	ch, err := watcher.Watch(ctx, prefix)
+	if err != nil {
+		writeJSONResponse(w, http.StatusInternalServerError, internalErrorResponseBytes)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// replayedThrough tracks the highest Rev this client has already seen
+	// via the ring-buffer replay below, so the live loop can drop anything
+	// off ch that duplicates it. Without this, an event published in the
+	// window between subscribing above and replaying here would land in
+	// both the replay batch and ch, and get sent to the client twice.
+	replayedThrough := fromRev
+	if fromRev > 0 {
+		for _, ev := range watcher.EventsSince(prefix, fromRev) {
+			writeSSEEvent(w, ev)
+			if ev.Rev > replayedThrough {
+				replayedThrough = ev.Rev
+			}
+		}
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.Rev <= replayedThrough {
+				continue
+			}
+			writeSSEEvent(w, ev)
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent writes ev as a single Server-Sent Events message, using its
+// Rev as the event ID so a client's EventSource can resume with Last-Event-ID.
+func writeSSEEvent(w http.ResponseWriter, ev models.Event) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.Rev, payload)
+}
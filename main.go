@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"Key_Value_Cache_Ass/controllers"
+	"Key_Value_Cache_Ass/models"
 	"Key_Value_Cache_Ass/routes"
 )
 
@@ -30,7 +33,19 @@ func main() {
 	<-stop
 	log.Println("Shutting down server...")
 
+	// Persist a final snapshot before tearing down background goroutines,
+	// if the configured tier chain supports it.
+	if snap, ok := controllers.CacheInstance.(models.Snapshotter); ok {
+		snapshotCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := snap.SaveSnapshot(snapshotCtx); err != nil {
+			log.Printf("Failed to save snapshot on shutdown: %v", err)
+		}
+		cancel()
+	}
+
 	// Clean up resources
-	controllers.CacheInstance.Close()
+	if err := controllers.CacheInstance.Close(); err != nil {
+		log.Printf("Error closing cache: %v", err)
+	}
 	log.Println("Server gracefully stopped")
 }
@@ -0,0 +1,99 @@
+// Package metrics exposes the Prometheus collectors for the cache and HTTP
+// layers, registered against the default registry and served at /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	CacheHitsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Total number of Get calls that found the key.",
+	})
+
+	CacheMissesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Total number of Get calls that did not find the key.",
+	})
+
+	CacheEvictionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_evictions_total",
+		Help: "Total number of keys removed from the cache, labeled by reason (expired, memory).",
+	}, []string{"reason"})
+
+	CacheItems = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_items",
+		Help: "Current number of keys held in the cache.",
+	})
+
+	CacheMemoryBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_memory_bytes",
+		Help: "Estimated memory used by cached keys and values, in bytes.",
+	})
+
+	CacheShardSize = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cache_shard_size",
+		Help: "Current number of keys held by each shard.",
+	}, []string{"shard"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, labeled by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "code"})
+
+	HTTPInflight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_inflight",
+		Help: "Number of HTTP requests currently being handled.",
+	})
+
+	HTTPRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rejected_total",
+		Help: "Total number of HTTP requests rejected before being served, labeled by reason.",
+	}, []string{"reason"})
+
+	DroppedEventsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dropped_events_total",
+		Help: "Total number of watch events dropped because a subscriber's channel was full.",
+	})
+)
+
+// Handler returns the HTTP handler that serves the registered collectors.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// statusRecorder captures the status code written by the wrapped handler so
+// Wrap can label http_request_duration_seconds with it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Wrap instruments an http.HandlerFunc with in-flight tracking and a
+// route/code-labeled latency histogram.
+func Wrap(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		HTTPInflight.Inc()
+		defer HTTPInflight.Dec()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(rec, r)
+
+		HTTPRequestDuration.WithLabelValues(route, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	}
+}
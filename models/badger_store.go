@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// BadgerStore is an on-disk L2 Store backed by BadgerDB. It exists for
+// deployments that want durability and capacity beyond RAM without paying
+// for a network hop to a remote Redis tier.
+type BadgerStore struct {
+	db *badger.DB
+}
+
+// NewBadgerStore opens (creating if necessary) a BadgerDB database rooted
+// at dir.
+func NewBadgerStore(dir string) (*BadgerStore, error) {
+	opts := badger.DefaultOptions(dir).WithLogger(nil)
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BadgerStore{db: db}, nil
+}
+
+func (s *BadgerStore) Get(ctx context.Context, key string) (string, bool, error) {
+	var val string
+	found := false
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return item.Value(func(v []byte) error {
+			val = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return val, found, nil
+}
+
+func (s *BadgerStore) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry([]byte(key), []byte(value))
+		if ttl > 0 {
+			entry = entry.WithTTL(ttl)
+		}
+		return txn.SetEntry(entry)
+	})
+}
+
+func (s *BadgerStore) Delete(ctx context.Context, key string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		err := txn.Delete([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+func (s *BadgerStore) Close() error {
+	return s.db.Close()
+}
+
+// TTL reports key's remaining time-to-live, satisfying TTLReader. BadgerDB
+// tracks expiry per-entry internally (see Put's use of WithTTL), so this
+// just reads it back off the item.
+func (s *BadgerStore) TTL(key string) (time.Duration, bool) {
+	var ttl time.Duration
+	found := false
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		expiresAt := item.ExpiresAt()
+		if expiresAt == 0 {
+			ttl = -1
+			return nil
+		}
+		ttl = time.Until(time.Unix(int64(expiresAt), 0))
+		return nil
+	})
+	if err != nil {
+		return 0, false
+	}
+	return ttl, found
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucketName is the single bucket this store keeps all keys in; Bolt
+// has no notion of a default namespace so every adapter needs one.
+var boltBucketName = []byte("cache")
+
+// BoltStore is an on-disk L2 Store backed by BoltDB. Unlike BadgerStore it
+// has no native per-key TTL, so expiry is tracked by prefixing the stored
+// value with its absolute expiry (UnixNano, 0 for "no expiry") and checked
+// lazily on Get.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB database at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(ctx context.Context, key string) (string, bool, error) {
+	var value string
+	var expireAt int64
+	found := false
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltBucketName).Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		expireAt, value = decodeBoltValue(raw)
+		return nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if !found {
+		return "", false, nil
+	}
+	if expireAt != 0 && time.Now().UnixNano() >= expireAt {
+		_ = s.Delete(ctx, key)
+		return "", false, nil
+	}
+	return value, true, nil
+}
+
+func (s *BoltStore) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	var expireAt int64
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl).UnixNano()
+	}
+	raw := encodeBoltValue(expireAt, value)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucketName).Put([]byte(key), raw)
+	})
+}
+
+func (s *BoltStore) Delete(ctx context.Context, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucketName).Delete([]byte(key))
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// TTL reports key's remaining time-to-live, satisfying TTLReader. It re-reads
+// the same absolute-expiry encoding Get checks, rather than deleting an
+// expired key itself, since reporting "not found" is enough for callers.
+func (s *BoltStore) TTL(key string) (time.Duration, bool) {
+	var expireAt int64
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltBucketName).Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		expireAt, _ = decodeBoltValue(raw)
+		return nil
+	})
+	if err != nil || !found {
+		return 0, false
+	}
+	if expireAt == 0 {
+		return -1, true
+	}
+	if time.Now().UnixNano() >= expireAt {
+		return 0, false
+	}
+	return time.Unix(0, expireAt).Sub(time.Now()), true
+}
+
+// encodeBoltValue/decodeBoltValue pack the absolute expiry alongside the
+// value so a single Get can answer both "what's the value" and "has it
+// expired" without a second lookup.
+func encodeBoltValue(expireAt int64, value string) []byte {
+	buf := make([]byte, 8+len(value))
+	for i := 0; i < 8; i++ {
+		buf[i] = byte(expireAt >> (56 - 8*i))
+	}
+	copy(buf[8:], value)
+	return buf
+}
+
+func decodeBoltValue(raw []byte) (int64, string) {
+	if len(raw) < 8 {
+		return 0, ""
+	}
+	var expireAt int64
+	for i := 0; i < 8; i++ {
+		expireAt = expireAt<<8 | int64(raw[i])
+	}
+	return expireAt, string(raw[8:])
+}
@@ -0,0 +1,302 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// errNoWatchTier is returned by ChainCache.Watch when none of its tiers
+// implement Watcher.
+var errNoWatchTier = errors.New("models: no tier in this chain supports Watch")
+
+// Tier configuration is environment-driven, matching the pattern already
+// used for persistence (CachePersistenceModeEnv and friends): unset
+// CacheTiersEnv and the chain degrades to a single in-memory tier, so
+// existing deployments behave exactly as before.
+const (
+	CacheTiersEnv            = "CACHE_TIERS"              // e.g. "memory,badger,redis"
+	CacheAsyncLowerWritesEnv = "CACHE_ASYNC_LOWER_WRITES" // "true" to Put to every tier after the first in the background
+	CacheBadgerDirEnv        = "CACHE_BADGER_DIR"
+	CacheBoltPathEnv         = "CACHE_BOLT_PATH"
+	CacheRedisAddrEnv        = "CACHE_REDIS_ADDR"
+
+	tierNameMemory = "memory"
+	tierNameBadger = "badger"
+	tierNameBolt   = "bolt"
+	tierNameRedis  = "redis"
+
+	defaultBadgerDir = "data/badger"
+	defaultBoltPath  = "data/bolt.db"
+)
+
+// ChainCache fans a single Store contract out across multiple backing
+// tiers, cheapest/fastest first. Get walks the chain in order and stops at
+// the first hit, back-filling the tiers it skipped over so the next lookup
+// is served from L1. Put and Delete are applied to every tier, mirroring
+// the write-through behaviour of gocache's chained store.
+type ChainCache struct {
+	tiers []Store
+	// asyncLowerWrites, when set, applies Put to tiers[1:] in a background
+	// goroutine instead of blocking the caller on slower on-disk/remote
+	// stores. Delete is always applied to every tier synchronously.
+	asyncLowerWrites bool
+}
+
+// NewChainCache builds a ChainCache from an explicit, ordered tier list.
+// tiers[0] is consulted first on Get and is always written synchronously.
+func NewChainCache(asyncLowerWrites bool, tiers ...Store) *ChainCache {
+	return &ChainCache{tiers: tiers, asyncLowerWrites: asyncLowerWrites}
+}
+
+// NewChainCacheFromEnv builds a ChainCache from CacheTiersEnv, a
+// comma-separated tier list such as "memory,badger,redis". An unset or
+// empty CacheTiersEnv falls back to a single in-memory tier so that
+// deployments that never opt into multi-tier caching see no behaviour
+// change. Tiers that fail to open are logged and skipped rather than
+// aborting startup, matching the best-effort style of loadPersistedState.
+func NewChainCacheFromEnv() *ChainCache {
+	names := os.Getenv(CacheTiersEnv)
+	if names == "" {
+		names = tierNameMemory
+	}
+
+	async := os.Getenv(CacheAsyncLowerWritesEnv) == "true"
+
+	var tiers []Store
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		tier, err := newTierFromName(name)
+		if err != nil {
+			log.Printf("models: skipping cache tier %q: %v", name, err)
+			continue
+		}
+		tiers = append(tiers, tier)
+	}
+
+	if len(tiers) == 0 {
+		log.Printf("models: no usable cache tiers configured, falling back to in-memory only")
+		tiers = []Store{NewCache()}
+	}
+
+	return NewChainCache(async, tiers...)
+}
+
+func newTierFromName(name string) (Store, error) {
+	switch name {
+	case tierNameMemory:
+		return NewCache(), nil
+	case tierNameBadger:
+		dir := os.Getenv(CacheBadgerDirEnv)
+		if dir == "" {
+			dir = defaultBadgerDir
+		}
+		return NewBadgerStore(dir)
+	case tierNameBolt:
+		path := os.Getenv(CacheBoltPathEnv)
+		if path == "" {
+			path = defaultBoltPath
+		}
+		return NewBoltStore(path)
+	case tierNameRedis:
+		addr := os.Getenv(CacheRedisAddrEnv)
+		return NewRedisStore(addr)
+	default:
+		return nil, errUnknownTier(name)
+	}
+}
+
+type errUnknownTier string
+
+func (e errUnknownTier) Error() string {
+	return "unknown cache tier \"" + string(e) + "\""
+}
+
+// Get walks the chain in order and returns the first hit, back-filling the
+// tiers it skipped over so the next lookup is served from the fastest one.
+func (c *ChainCache) Get(ctx context.Context, key string) (string, bool, error) {
+	for i, tier := range c.tiers {
+		val, found, err := tier.Get(ctx, key)
+		if err != nil {
+			return "", false, err
+		}
+		if !found {
+			continue
+		}
+		if i > 0 {
+			c.backfill(c.tiers[:i], key, val, remainingTTL(tier, key))
+		}
+		return val, true, nil
+	}
+	return "", false, nil
+}
+
+// remainingTTL reports how much longer key should live in tier, for
+// propagating an expiry into a backfill. Tiers that don't implement
+// TTLReader are assumed permanent, matching their own Put contract (ttl=0
+// means "no expiry").
+func remainingTTL(tier Store, key string) time.Duration {
+	reader, ok := tier.(TTLReader)
+	if !ok {
+		return 0
+	}
+	ttl, found := reader.TTL(key)
+	if !found || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// backfill writes a value found at a lower tier back into the faster tiers
+// above it, carrying over the source tier's remaining TTL so the backfilled
+// copy expires at the same time the original does rather than becoming
+// permanent. Always asynchronous: a backfill miss or slow write should never
+// hold up the Get that triggered it.
+func (c *ChainCache) backfill(tiers []Store, key, val string, ttl time.Duration) {
+	go func() {
+		for _, tier := range tiers {
+			if err := tier.Put(context.Background(), key, val, ttl); err != nil {
+				log.Printf("models: chain backfill failed for key %q: %v", key, err)
+			}
+		}
+	}()
+}
+
+// Put writes through every tier. tiers[0] is always written synchronously
+// so a subsequent Get against the chain observes the value immediately;
+// the remaining tiers follow the asyncLowerWrites setting.
+func (c *ChainCache) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	if len(c.tiers) == 0 {
+		return nil
+	}
+	if err := c.tiers[0].Put(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	lower := c.tiers[1:]
+	if len(lower) == 0 {
+		return nil
+	}
+
+	if c.asyncLowerWrites {
+		go func() {
+			for _, tier := range lower {
+				if err := tier.Put(context.Background(), key, value, ttl); err != nil {
+					log.Printf("models: async write-through failed for key %q: %v", key, err)
+				}
+			}
+		}()
+		return nil
+	}
+
+	for _, tier := range lower {
+		if err := tier.Put(ctx, key, value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes key from every tier, returning the first error encountered.
+func (c *ChainCache) Delete(ctx context.Context, key string) error {
+	var firstErr error
+	for _, tier := range c.tiers {
+		if err := tier.Delete(ctx, key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes every tier, returning the first error encountered.
+func (c *ChainCache) Close() error {
+	var firstErr error
+	for _, tier := range c.tiers {
+		if err := tier.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SaveSnapshot forwards to the first tier that supports it (in practice the
+// in-memory L1), satisfying Snapshotter so main's shutdown path keeps
+// working unchanged.
+func (c *ChainCache) SaveSnapshot(ctx context.Context) error {
+	for _, tier := range c.tiers {
+		if snap, ok := tier.(Snapshotter); ok {
+			return snap.SaveSnapshot(ctx)
+		}
+	}
+	return nil
+}
+
+// ttlTier returns the first tier implementing TTLStore (in practice the
+// in-memory L1), so that ChainCache itself satisfies TTLStore as long as
+// one of its tiers does.
+func (c *ChainCache) ttlTier() (TTLStore, bool) {
+	for _, tier := range c.tiers {
+		if ttlTier, ok := tier.(TTLStore); ok {
+			return ttlTier, true
+		}
+	}
+	return nil, false
+}
+
+func (c *ChainCache) TTL(key string) (time.Duration, bool) {
+	tier, ok := c.ttlTier()
+	if !ok {
+		return 0, false
+	}
+	return tier.TTL(key)
+}
+
+func (c *ChainCache) Expire(key string, ttl time.Duration) bool {
+	tier, ok := c.ttlTier()
+	if !ok {
+		return false
+	}
+	return tier.Expire(key, ttl)
+}
+
+func (c *ChainCache) Persist(key string) bool {
+	tier, ok := c.ttlTier()
+	if !ok {
+		return false
+	}
+	return tier.Persist(key)
+}
+
+// watchTier returns the first tier implementing Watcher (in practice the
+// in-memory L1), so that ChainCache itself satisfies Watcher as long as one
+// of its tiers does.
+func (c *ChainCache) watchTier() (Watcher, bool) {
+	for _, tier := range c.tiers {
+		if watchTier, ok := tier.(Watcher); ok {
+			return watchTier, true
+		}
+	}
+	return nil, false
+}
+
+func (c *ChainCache) Watch(ctx context.Context, keyPrefix string) (<-chan Event, error) {
+	tier, ok := c.watchTier()
+	if !ok {
+		return nil, errNoWatchTier
+	}
+	return tier.Watch(ctx, keyPrefix)
+}
+
+func (c *ChainCache) EventsSince(keyPrefix string, fromRev uint64) []Event {
+	tier, ok := c.watchTier()
+	if !ok {
+		return nil
+	}
+	return tier.EventsSince(keyPrefix, fromRev)
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeTTLTier is a minimal Store + TTLReader used to exercise ChainCache's
+// backfill path without standing up a real Badger/Bolt/Redis tier.
+type fakeTTLTier struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newFakeTTLTier() *fakeTTLTier {
+	return &fakeTTLTier{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeTTLTier) Get(ctx context.Context, key string) (string, bool, error) {
+	val, ok := f.values[key]
+	return val, ok, nil
+}
+
+func (f *fakeTTLTier) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	f.values[key] = value
+	f.ttls[key] = ttl
+	return nil
+}
+
+func (f *fakeTTLTier) Delete(ctx context.Context, key string) error {
+	delete(f.values, key)
+	delete(f.ttls, key)
+	return nil
+}
+
+func (f *fakeTTLTier) Close() error { return nil }
+
+func (f *fakeTTLTier) TTL(key string) (time.Duration, bool) {
+	ttl, ok := f.ttls[key]
+	if !ok {
+		return 0, false
+	}
+	return ttl, true
+}
+
+func TestChainBackfillPropagatesTTL(t *testing.T) {
+	ctx := context.Background()
+
+	l1 := NewCache()
+	defer l1.Close()
+	l2 := newFakeTTLTier()
+	l2.values["k"] = "v"
+	l2.ttls["k"] = 50 * time.Millisecond
+
+	chain := NewChainCache(false, l1, l2)
+
+	val, found, err := chain.Get(ctx, "k")
+	if err != nil || !found || val != "v" {
+		t.Fatalf("Get(k) = (%q, %v, %v), want (v, true, nil)", val, found, err)
+	}
+
+	// backfill always runs asynchronously, so poll for it to land.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok, _ := l1.Get(ctx, "k"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("backfill never wrote k into l1")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ttl, ok := l1.TTL("k")
+	if !ok {
+		t.Fatal("l1.TTL(k) reports missing after backfill")
+	}
+	if ttl == -1 {
+		t.Fatal("backfilled key became permanent instead of carrying over l2's TTL")
+	}
+	if ttl <= 0 || ttl > 50*time.Millisecond {
+		t.Fatalf("l1.TTL(k) = %v, want a positive duration <= 50ms", ttl)
+	}
+}
+
+func TestChainBackfillWithoutTTLReaderStaysPermanent(t *testing.T) {
+	ctx := context.Background()
+
+	l1 := NewCache()
+	defer l1.Close()
+	l2 := &plainTier{values: map[string]string{"k": "v"}}
+
+	chain := NewChainCache(false, l1, l2)
+
+	if _, found, err := chain.Get(ctx, "k"); err != nil || !found {
+		t.Fatalf("Get(k) = (_, %v, %v), want (_, true, nil)", found, err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok, _ := l1.Get(ctx, "k"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("backfill never wrote k into l1")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if ttl, ok := l1.TTL("k"); !ok || ttl != -1 {
+		t.Fatalf("l1.TTL(k) = (%v, %v), want (-1, true) for a tier with no TTL info", ttl, ok)
+	}
+}
+
+// plainTier is a Store with no TTL information at all, standing in for a
+// tier that doesn't implement TTLReader.
+type plainTier struct {
+	values map[string]string
+}
+
+func (p *plainTier) Get(ctx context.Context, key string) (string, bool, error) {
+	val, ok := p.values[key]
+	return val, ok, nil
+}
+
+func (p *plainTier) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	p.values[key] = value
+	return nil
+}
+
+func (p *plainTier) Delete(ctx context.Context, key string) error {
+	delete(p.values, key)
+	return nil
+}
+
+func (p *plainTier) Close() error { return nil }
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	costTrackerInterval = 1 * time.Second
+	costTrackerAlpha    = 0.3 // EWMA smoothing factor for both rates
+
+	// maxEvictionBatchMultiple bounds how far costTracker will scale
+	// evictionBatch above its starting value, so a sustained write burst
+	// can't turn eviction into an unbounded stop-the-world sweep.
+	maxEvictionBatchMultiple = 10
+)
+
+// costTracker measures a moving average of bytes evicted per second against
+// bytes written per second and scales ShardedCache.evictionBatch up when
+// eviction is falling behind Put throughput, borrowing the idea of pricing
+// access to a shared resource by its actual cost from Ethereum LES's
+// cost-based request serving.
+type costTracker struct {
+	cache *ShardedCache
+
+	evictedBytes int64 // accumulated since the last tick, atomic
+	putBytes     int64 // accumulated since the last tick, atomic
+
+	evictRate float64 // EWMA bytes evicted/sec
+	putRate   float64 // EWMA bytes put/sec
+}
+
+func newCostTracker(cache *ShardedCache) *costTracker {
+	return &costTracker{cache: cache}
+}
+
+// recordEviction credits bytes reclaimed by a completed eviction pass.
+func (t *costTracker) recordEviction(bytes int64) {
+	atomic.AddInt64(&t.evictedBytes, bytes)
+}
+
+// recordPut credits bytes written by a completed Put.
+func (t *costTracker) recordPut(bytes int64) {
+	atomic.AddInt64(&t.putBytes, bytes)
+}
+
+// run periodically recomputes the moving averages and retunes
+// cache.evictionBatch. It exits when cache.stopChan is closed.
+func (t *costTracker) run() {
+	ticker := time.NewTicker(costTrackerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.tick()
+		case <-t.cache.stopChan:
+			return
+		}
+	}
+}
+
+func (t *costTracker) tick() {
+	seconds := costTrackerInterval.Seconds()
+	evicted := float64(atomic.SwapInt64(&t.evictedBytes, 0)) / seconds
+	put := float64(atomic.SwapInt64(&t.putBytes, 0)) / seconds
+
+	t.evictRate = costTrackerAlpha*evicted + (1-costTrackerAlpha)*t.evictRate
+	t.putRate = costTrackerAlpha*put + (1-costTrackerAlpha)*t.putRate
+
+	base := int64(EvictionBatchSize)
+	max := base * maxEvictionBatchMultiple
+	current := atomic.LoadInt64(&t.cache.evictionBatch)
+
+	switch {
+	case t.putRate > t.evictRate && current < max:
+		// Eviction can't keep up with writes; widen the batch so each
+		// pass reclaims more before the next tick.
+		next := current + current/2 // grow by 50%
+		if next > max {
+			next = max
+		}
+		atomic.StoreInt64(&t.cache.evictionBatch, next)
+	case t.putRate <= t.evictRate && current > base:
+		// Eviction is comfortably keeping up; ease back toward baseline
+		// so a quiet cache doesn't keep paying for oversized passes.
+		next := current - current/4 // shrink by 25%
+		if next < base {
+			next = base
+		}
+		atomic.StoreInt64(&t.cache.evictionBatch, next)
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"Key_Value_Cache_Ass/metrics"
+)
+
+// MetricsReportInterval controls how often the per-shard/total gauges are
+// recomputed and pushed into the metrics package.
+const MetricsReportInterval = 2 * time.Second
+
+// reportMetrics periodically republishes the gauge-style metrics (item
+// counts, memory usage, per-shard sizes) that aren't cheap to update on
+// every single Get/Put. Mirrors the ticker-driven shape of monitorMemory.
+func (c *ShardedCache) reportMetrics() {
+	ticker := time.NewTicker(MetricsReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.updateMetrics()
+		case <-c.stopChan:
+			return
+		}
+	}
+}
+
+func (c *ShardedCache) updateMetrics() {
+	metrics.CacheMemoryBytes.Set(float64(atomic.LoadInt64(&c.memUsage)))
+
+	total := 0
+	for i, shard := range c.shards {
+		shard.mu.RLock()
+		size := len(shard.items)
+		shard.mu.RUnlock()
+
+		total += size
+		metrics.CacheShardSize.WithLabelValues(strconv.Itoa(i)).Set(float64(size))
+	}
+
+	metrics.CacheItems.Set(float64(total))
+}
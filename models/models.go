@@ -2,90 +2,213 @@ package models
 
 import (
 	"container/list"
+	"context"
+	"log"
 	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"Key_Value_Cache_Ass/metrics"
 )
 
 const (
 	DefaultShards     = 1024
-	MemCheckInterval  = 2 * time.Second  // Reduced interval for faster response to memory pressure
-	MaxMemoryPercent  = 0.7 // 70% memory threshold
-	EvictionBatchSize = 200  // Increased batch size for more aggressive eviction
+	MemCheckInterval  = 2 * time.Second // Reduced interval for faster response to memory pressure
+	MaxMemoryPercent  = 0.7             // 70% memory threshold
+	EvictionBatchSize = 200             // Starting batch size; costTracker scales this up under pressure
+
+	ReapInterval            = 100 * time.Millisecond // active-expiration cycle, Redis-style
+	ReapSampleSize          = 20                     // keys sampled per shard per cycle
+	ReapAggressiveThreshold = 0.25                   // resample immediately if >25% of the sample was expired
+
+	// fixedItemOverhead approximates the bookkeeping cost of a cache entry
+	// (map buckets, list.Element, ClockItem struct) beyond its key/value
+	// bytes, so small, numerous keys are weighed fairly against a few large
+	// ones during eviction.
+	fixedItemOverhead = 48
+
+	// hotRingTargetFraction caps the hot ring at a fraction of a shard's
+	// tracked bytes; past that the hot hand starts sweeping to push
+	// rarely-used hot items back down to the test ring.
+	hotRingTargetFraction = 0.10
 )
 
-// ShardedCache implements a sharded cache with CLOCK eviction
+// ShardedCache implements a sharded cache with a CLOCK-Pro-style,
+// cost-weighted eviction scheme.
 type ShardedCache struct {
-	shards       []*CacheShard
-	shardCount   int
-	maxMemoryPct float64
-	memUsage     int64
-	stopChan     chan struct{}
+	shards        []*CacheShard
+	shardCount    int
+	maxMemoryPct  float64
+	memUsage      int64
+	evictionBatch int64 // current eviction batch size, tuned by costTracker
+	rev           int64 // monotonic revision counter for watch Events
+	stopChan      chan struct{}
+	aof           *aofLog // non-nil when append-only-file persistence is enabled
+	costs         *costTracker
+
+	// persistenceEnabled is set by loadPersistedState when
+	// CachePersistenceModeEnv asks for snapshot or AOF persistence. It gates
+	// SaveSnapshot so that a deployment which never opted into persistence
+	// doesn't get a surprise data/ directory written on graceful shutdown.
+	persistenceEnabled bool
 }
 
-// CacheShard represents a single shard in the cache
+// CacheShard represents a single shard in the cache. Items live in one of
+// two rings: coldList (the "test" ring, holding both never-hot and
+// demoted-from-hot items) and hotList (frequently re-referenced items).
+// Each ring has its own CLOCK hand.
 type CacheShard struct {
-	items     map[string]string
-	elements  map[string]*list.Element  // Direct element access for O(1) lookups
-	clockHand *list.Element
-	itemsList *list.List
-	mu        sync.RWMutex
+	items    map[string]string
+	elements map[string]*list.Element // Direct element access for O(1) lookups
+	coldList *list.List
+	hotList  *list.List
+	coldHand *list.Element
+	hotHand  *list.Element
+	hotBytes int64 // sum of cost across items currently in hotList
+	mu       sync.RWMutex
+
+	// subMu guards subs (the watchers registered against this shard) and
+	// the ring/ringPos catch-up buffer; see watch.go.
+	subMu   sync.RWMutex
+	subs    []*watcher
+	ring    []Event
+	ringPos int
+
+	// owner lets shard methods that don't otherwise take a *ShardedCache
+	// (namely evict) mint a watch Event's revision number.
+	owner *ShardedCache
 }
 
-// ClockItem represents an item in the CLOCK cache
+// ClockItem represents an item tracked by the cost-weighted CLOCK-Pro ring.
 type ClockItem struct {
 	key        string
 	referenced bool
-	size       int64  // Track size for better memory management
+	size       int64 // key+value bytes
+	cost       int64 // size + fixedItemOverhead, what eviction actually weighs
+	hot        bool  // true while resident in the hot ring
+	expireAt   int64 // Unix nanos; 0 means the key never expires
 }
 
 // NewCache creates a new sharded cache
 func NewCache() *ShardedCache {
 	cache := &ShardedCache{
-		shards:       make([]*CacheShard, DefaultShards),
-		shardCount:   DefaultShards,
-		maxMemoryPct: MaxMemoryPercent,
-		stopChan:     make(chan struct{}),
+		shards:        make([]*CacheShard, DefaultShards),
+		shardCount:    DefaultShards,
+		maxMemoryPct:  MaxMemoryPercent,
+		evictionBatch: EvictionBatchSize,
+		stopChan:      make(chan struct{}),
 	}
+	cache.costs = newCostTracker(cache)
 
 	for i := 0; i < DefaultShards; i++ {
 		cache.shards[i] = &CacheShard{
-			items:     make(map[string]string),
-			elements:  make(map[string]*list.Element),  // Initialize elements map
-			itemsList: list.New(),
+			items:    make(map[string]string),
+			elements: make(map[string]*list.Element), // Initialize elements map
+			coldList: list.New(),
+			hotList:  list.New(),
+			owner:    cache,
 		}
 	}
 
 	// Start memory monitor goroutine
 	go cache.monitorMemory()
 
+	// Start active-expiration reaper goroutine
+	go cache.reapExpired()
+
+	// Start Prometheus gauge reporter goroutine
+	go cache.reportMetrics()
+
+	// Start the eviction-rate cost tracker, which scales evictionBatch up
+	// when eviction can't keep up with Put throughput.
+	go cache.costs.run()
+
+	// If persistence is enabled, replay any snapshot/AOF on disk and start
+	// persistence background goroutines.
+	cache.loadPersistedState()
+
 	return cache
 }
 
-// Get retrieves a value from the cache
-func (c *ShardedCache) Get(key string) (string, bool) {
+// Get retrieves a value from the cache, satisfying the Store interface. An
+// expired entry is treated as a miss and is lazily evicted. The in-memory
+// tier never errors once ctx has not already been cancelled.
+func (c *ShardedCache) Get(ctx context.Context, key string) (string, bool, error) {
+	select {
+	case <-ctx.Done():
+		return "", false, ctx.Err()
+	default:
+	}
+
 	shard := c.getShard(key)
 	shard.mu.RLock()
-	defer shard.mu.RUnlock()
 
-	if val, ok := shard.items[key]; ok {
-		// O(1) lookup for element using the elements map
-		if elem, found := shard.elements[key]; found {
-			item := elem.Value.(*ClockItem)
-			item.referenced = true
+	val, ok := shard.items[key]
+	if !ok {
+		shard.mu.RUnlock()
+		metrics.CacheMissesTotal.Inc()
+		return "", false, nil
+	}
+
+	// O(1) lookup for element using the elements map
+	elem, found := shard.elements[key]
+	if found {
+		item := elem.Value.(*ClockItem)
+		if item.expireAt != 0 && time.Now().UnixNano() >= item.expireAt {
+			shard.mu.RUnlock()
+			c.lazyExpire(shard, key)
+			metrics.CacheMissesTotal.Inc()
+			return "", false, nil
+		}
+		item.referenced = true
+		promote := !item.hot
+		shard.mu.RUnlock()
+
+		if promote {
+			shard.mu.Lock()
+			shard.promoteToHot(elem)
+			shard.mu.Unlock()
 		}
-		return val, true
+	} else {
+		shard.mu.RUnlock()
 	}
-	return "", false
+
+	metrics.CacheHitsTotal.Inc()
+	return val, true, nil
 }
 
-// Put adds or updates a value in the cache
-func (c *ShardedCache) Put(key, value string) {
+// Put adds or updates a value in the cache, satisfying the Store interface.
+// A ttl of zero or less means the key never expires.
+func (c *ShardedCache) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	c.putLocked(key, value, ttl)
+	c.costs.recordPut(int64(len(key) + len(value)))
+	return nil
+}
+
+// putLocked performs the actual insert/update and, while still holding the
+// shard lock, appends the AOF record for it. Appending inside the same
+// critical section as the mutation (rather than after releasing the lock)
+// guarantees the AOF sees writes to a given key in the same order they were
+// actually applied in memory; appending afterward would let two concurrent
+// Puts to the same key race each other to the log and potentially replay
+// the stale one as final.
+func (c *ShardedCache) putLocked(key, value string, ttl time.Duration) int64 {
 	shard := c.getShard(key)
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
 
+	var expireAt int64
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl).UnixNano()
+	}
+
 	// Calculate size for memory tracking
 	valueSize := int64(len(value))
 	keySize := int64(len(key))
@@ -95,44 +218,222 @@ func (c *ShardedCache) Put(key, value string) {
 	if oldVal, exists := shard.items[key]; exists {
 		// Update value
 		shard.items[key] = value
-		
+
 		// Update memory usage with size difference
 		oldSize := int64(len(oldVal))
 		sizeDiff := valueSize - oldSize
 		if sizeDiff != 0 {
 			atomic.AddInt64(&c.memUsage, sizeDiff)
 		}
-		
+
 		// Update referenced status using O(1) lookup
 		if elem, found := shard.elements[key]; found {
 			item := elem.Value.(*ClockItem)
 			item.referenced = true
 			item.size = totalSize
+			newCost := totalSize + fixedItemOverhead
+			if item.hot {
+				shard.hotBytes += newCost - item.cost
+			}
+			item.cost = newCost
+			item.expireAt = expireAt
 		}
-		return
+		shard.publish(Event{Type: EventPut, Key: key, Value: value, Rev: c.nextRev()})
+		c.appendAOFPutLocked(key, value, expireAt)
+		return expireAt
 	}
 
-	// Add new item
+	// Add new item. New items always enter cold (the test ring); they earn
+	// their way into the hot ring via promoteToHot on a subsequent Get.
 	shard.items[key] = value
-	
-	// Create new clock item with size information
+
 	item := &ClockItem{
 		key:        key,
 		referenced: true,
 		size:       totalSize,
+		cost:       totalSize + fixedItemOverhead,
+		expireAt:   expireAt,
 	}
-	
-	// Add to linked list and store reference in elements map
-	element := shard.itemsList.PushBack(item)
+
+	element := shard.coldList.PushBack(item)
 	shard.elements[key] = element
 
-	// Initialize clockHand if this is the first item
-	if shard.clockHand == nil {
-		shard.clockHand = element
+	if shard.coldHand == nil {
+		shard.coldHand = element
 	}
 
 	// Update memory usage estimate
 	atomic.AddInt64(&c.memUsage, totalSize)
+
+	shard.publish(Event{Type: EventPut, Key: key, Value: value, Rev: c.nextRev()})
+	c.appendAOFPutLocked(key, value, expireAt)
+	return expireAt
+}
+
+// appendAOFPutLocked appends a Put record to the AOF, if persistence is
+// enabled. Callers must already hold the shard lock for key, so that the
+// append lands in the log in the same order the mutation was applied.
+func (c *ShardedCache) appendAOFPutLocked(key, value string, expireAt int64) {
+	if c.aof == nil {
+		return
+	}
+	if err := c.aof.appendPut(key, value, expireAt); err != nil {
+		log.Printf("models: AOF append failed for key %q: %v", key, err)
+	}
+}
+
+// TTL returns the remaining time-to-live for key. The returned bool is
+// false if the key does not exist (or has already expired). A key that
+// exists but carries no expiration reports a negative duration, mirroring
+// Redis's TTL -1/-2 convention.
+func (c *ShardedCache) TTL(key string) (time.Duration, bool) {
+	shard := c.getShard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	elem, found := shard.elements[key]
+	if !found {
+		return 0, false
+	}
+
+	item := elem.Value.(*ClockItem)
+	if item.expireAt == 0 {
+		return -1, true
+	}
+
+	remaining := time.Until(time.Unix(0, item.expireAt))
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// Expire sets (or refreshes) the TTL on an existing key, returning whether
+// the key was present. A ttl of zero or less deletes the key immediately,
+// matching Redis's EXPIRE semantics.
+func (c *ShardedCache) Expire(key string, ttl time.Duration) bool {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, found := shard.elements[key]
+	if !found {
+		return false
+	}
+
+	if ttl <= 0 {
+		c.deleteLocked(shard, key)
+		return true
+	}
+
+	item := elem.Value.(*ClockItem)
+	item.expireAt = time.Now().Add(ttl).UnixNano()
+
+	value := shard.items[key]
+	shard.publish(Event{Type: EventPut, Key: key, Value: value, Rev: c.nextRev()})
+	c.appendAOFPutLocked(key, value, item.expireAt)
+	return true
+}
+
+// Persist removes any TTL on key, returning whether a TTL was actually
+// cleared (false if the key is missing or already has no expiration).
+func (c *ShardedCache) Persist(key string) bool {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, found := shard.elements[key]
+	if !found {
+		return false
+	}
+
+	item := elem.Value.(*ClockItem)
+	if item.expireAt == 0 {
+		return false
+	}
+	item.expireAt = 0
+
+	value := shard.items[key]
+	shard.publish(Event{Type: EventPut, Key: key, Value: value, Rev: c.nextRev()})
+	c.appendAOFPutLocked(key, value, 0)
+	return true
+}
+
+// Delete removes a key from the cache, satisfying the Store interface.
+func (c *ShardedCache) Delete(ctx context.Context, key string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	c.deleteLocked(shard, key)
+	shard.mu.Unlock()
+
+	return nil
+}
+
+// deleteLocked removes key from shard, which must already be write-locked
+// by the caller, credits the freed memory back to memUsage, and (while still
+// under that same lock) appends the AOF delete record so it lands in the log
+// in the same order the mutation was applied — mirroring putLocked's
+// appendAOFPutLocked call for the same reason.
+func (c *ShardedCache) deleteLocked(shard *CacheShard, key string) bool {
+	val, exists := shard.items[key]
+	if !exists {
+		return false
+	}
+	delete(shard.items, key)
+
+	if elem, found := shard.elements[key]; found {
+		item := elem.Value.(*ClockItem)
+		if item.hot {
+			shard.removeFromRing(shard.hotList, &shard.hotHand, elem)
+			shard.hotBytes -= item.cost
+		} else {
+			shard.removeFromRing(shard.coldList, &shard.coldHand, elem)
+		}
+		delete(shard.elements, key)
+	}
+
+	totalSize := int64(len(key)) + int64(len(val))
+	atomic.AddInt64(&c.memUsage, -totalSize)
+
+	shard.publish(Event{Type: EventDelete, Key: key, Value: val, Rev: c.nextRev()})
+	c.appendAOFDeleteLocked(key)
+	return true
+}
+
+// appendAOFDeleteLocked appends a Delete record to the AOF, if persistence
+// is enabled. Callers must already hold the shard lock for key, for the same
+// ordering reason as appendAOFPutLocked.
+func (c *ShardedCache) appendAOFDeleteLocked(key string) {
+	if c.aof == nil {
+		return
+	}
+	if err := c.aof.appendDelete(key); err != nil {
+		log.Printf("models: AOF append failed for key %q: %v", key, err)
+	}
+}
+
+// lazyExpire removes key from shard once Get has observed it past its
+// expireAt, re-checking under the write lock in case it was refreshed in
+// the meantime.
+func (c *ShardedCache) lazyExpire(shard *CacheShard, key string) {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, found := shard.elements[key]
+	if !found {
+		return
+	}
+	item := elem.Value.(*ClockItem)
+	if item.expireAt != 0 && time.Now().UnixNano() >= item.expireAt {
+		c.deleteLocked(shard, key)
+		metrics.CacheEvictionsTotal.WithLabelValues("expired").Inc()
+	}
 }
 
 // getShard returns the appropriate shard for a key
@@ -166,8 +467,9 @@ func (c *ShardedCache) monitorMemory() {
 			// Check if memory usage exceeds threshold
 			memRatio := float64(m.Alloc) / float64(m.Sys)
 			if memRatio > c.maxMemoryPct {
-				// More aggressive eviction when memory pressure is high
-				evictionCount := EvictionBatchSize
+				// More aggressive eviction when memory pressure is high;
+				// evictionBatch itself is tuned continuously by costs.run.
+				evictionCount := int(atomic.LoadInt64(&c.evictionBatch))
 				if memRatio > 0.85 {
 					evictionCount *= 2
 				}
@@ -179,59 +481,246 @@ func (c *ShardedCache) monitorMemory() {
 	}
 }
 
-// evictBatch evicts a batch of items using the CLOCK algorithm
+// reapExpired drives Redis-style active expiration: every ReapInterval it
+// samples a handful of keys per shard and deletes the ones that have
+// expired, repeating aggressively on a shard while the sample keeps coming
+// back mostly-expired.
+func (c *ShardedCache) reapExpired() {
+	ticker := time.NewTicker(ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, shard := range c.shards {
+				c.reapShard(shard)
+			}
+		case <-c.stopChan:
+			return
+		}
+	}
+}
+
+// reapShard repeatedly samples shard until a sample comes back under the
+// aggressive-expiration threshold (or the shard runs dry).
+func (c *ShardedCache) reapShard(shard *CacheShard) {
+	for {
+		expiredRatio, sampled := c.reapSample(shard)
+		if sampled == 0 || expiredRatio <= ReapAggressiveThreshold {
+			return
+		}
+	}
+}
+
+// reapSample deletes any expired keys among a random sample of up to
+// ReapSampleSize keys from shard, returning the fraction that were expired
+// and the sample size actually drawn. Go's map iteration order is already
+// randomized per-run, so ranging over shard.elements gives us the random
+// sample without a separate RNG.
+func (c *ShardedCache) reapSample(shard *CacheShard) (float64, int) {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if len(shard.elements) == 0 {
+		return 0, 0
+	}
+
+	sampleSize := ReapSampleSize
+	if sampleSize > len(shard.elements) {
+		sampleSize = len(shard.elements)
+	}
+
+	keys := make([]string, 0, sampleSize)
+	for key := range shard.elements {
+		keys = append(keys, key)
+		if len(keys) == sampleSize {
+			break
+		}
+	}
+
+	now := time.Now().UnixNano()
+	expired := 0
+	for _, key := range keys {
+		item := shard.elements[key].Value.(*ClockItem)
+		if item.expireAt != 0 && now >= item.expireAt {
+			c.deleteLocked(shard, key)
+			expired++
+		}
+	}
+
+	if expired > 0 {
+		metrics.CacheEvictionsTotal.WithLabelValues("expired").Add(float64(expired))
+	}
+
+	return float64(expired) / float64(len(keys)), len(keys)
+}
+
+// evictBatch evicts a batch of items using the cost-weighted CLOCK-Pro ring.
 func (c *ShardedCache) evictBatch(count int) {
 	evicted := 0
+	var evictedBytes int64
 	// Distribute eviction across shards
 	perShardCount := count / c.shardCount
 	if perShardCount < 1 {
 		perShardCount = 1
 	}
-	
+
+	// Each shard's hot ring is capped relative to its share of the cache's
+	// overall tracked bytes, so the target scales with actual memory use
+	// rather than a fixed per-shard constant.
+	hotTarget := int64(hotRingTargetFraction * float64(atomic.LoadInt64(&c.memUsage)) / float64(c.shardCount))
+
 	for i := 0; i < c.shardCount && evicted < count; i++ {
 		shard := c.shards[i]
-		evicted += shard.evict(perShardCount)
+		n, bytes := shard.evict(perShardCount, hotTarget)
+		evicted += n
+		evictedBytes += bytes
+	}
+
+	if evicted > 0 {
+		metrics.CacheEvictionsTotal.WithLabelValues("memory").Add(float64(evicted))
+		c.costs.recordEviction(evictedBytes)
 	}
 }
 
-// evict implements the CLOCK algorithm for a single shard
-func (s *CacheShard) evict(count int) int {
+// evict sweeps the cold hand for up to count evictions, occasionally
+// stepping the hot hand first if the hot ring has grown past hotTargetBytes.
+// It returns the number of items evicted and the bytes (cost) reclaimed.
+func (s *CacheShard) evict(count int, hotTargetBytes int64) (int, int64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.clockHand == nil || s.itemsList.Len() == 0 {
-		return 0
+	if hotTargetBytes > 0 && s.hotBytes > hotTargetBytes {
+		s.sweepHot()
+	}
+
+	if s.coldHand == nil || s.coldList.Len() == 0 {
+		return 0, 0
 	}
 
 	evicted := 0
+	var reclaimed int64
 	attempts := 0
-	maxAttempts := s.itemsList.Len() * 2 // Prevent infinite loops
-	
+	maxAttempts := s.coldList.Len()*2 + 1 // Prevent infinite loops
+
 	for evicted < count && attempts < maxAttempts {
 		attempts++
-		item := s.clockHand.Value.(*ClockItem)
+		elem := s.coldHand
+		item := elem.Value.(*ClockItem)
 
 		if item.referenced {
-			// Give a second chance
+			// Give a second chance before giving up on it.
 			item.referenced = false
-			s.clockHand = nextOrFirst(s.clockHand, s.itemsList)
-		} else {
-			// Evict this item
-			next := nextOrFirst(s.clockHand, s.itemsList)
-			delete(s.items, item.key)
-			delete(s.elements, item.key)  // Clean up elements map
-			s.itemsList.Remove(s.clockHand)
-			s.clockHand = next
-			evicted++
-
-			if s.itemsList.Len() == 0 {
-				s.clockHand = nil
-				break
-			}
+			s.coldHand = nextOrFirst(elem, s.coldList)
+			continue
+		}
+
+		// Evict this item.
+		s.coldHand = nextOrFirst(elem, s.coldList)
+		if s.coldHand == elem {
+			s.coldHand = nil
+		}
+		val := s.items[item.key]
+		delete(s.items, item.key)
+		delete(s.elements, item.key)
+		s.coldList.Remove(elem)
+		evicted++
+		reclaimed += item.cost
+		atomic.AddInt64(&s.owner.memUsage, -item.size)
+		s.publish(Event{Type: EventEvict, Key: item.key, Value: val, Rev: s.owner.nextRev()})
+
+		if s.coldList.Len() == 0 {
+			s.coldHand = nil
+			break
+		}
+	}
+
+	return evicted, reclaimed
+}
+
+// promoteToHot moves a cold item into the hot ring on re-reference,
+// demoting the coldest (least recently referenced) hot item back to the
+// test ring to keep the swap roughly one-for-one. elem must belong to
+// s.coldList; the caller must already hold s.mu.
+func (s *CacheShard) promoteToHot(elem *list.Element) {
+	item := elem.Value.(*ClockItem)
+	if item.hot || s.elements[item.key] != elem {
+		// Already promoted, or deleted/evicted, by a racing Get/Put/evict
+		// between our RUnlock and this Lock.
+		return
+	}
+
+	s.removeFromRing(s.coldList, &s.coldHand, elem)
+	item.hot = true
+	newElem := s.hotList.PushBack(item)
+	s.elements[item.key] = newElem
+	s.hotBytes += item.cost
+	if s.hotHand == nil {
+		s.hotHand = newElem
+	}
+
+	s.demoteColdestHot()
+}
+
+// demoteColdestHot walks the hot hand, clearing referenced bits, until it
+// finds an unreferenced item (or has gone all the way around), and moves
+// that one item back to the cold ring. The caller must already hold s.mu.
+func (s *CacheShard) demoteColdestHot() {
+	if s.hotHand == nil || s.hotList.Len() == 0 {
+		return
+	}
+
+	attempts := s.hotList.Len()
+	for ; attempts > 0; attempts-- {
+		elem := s.hotHand
+		item := elem.Value.(*ClockItem)
+
+		if item.referenced {
+			item.referenced = false
+			s.hotHand = nextOrFirst(elem, s.hotList)
+			continue
 		}
+
+		s.hotHand = nextOrFirst(elem, s.hotList)
+		if s.hotHand == elem {
+			s.hotHand = nil
+		}
+		s.removeFromRing(s.hotList, &s.hotHand, elem)
+		item.hot = false
+		s.hotBytes -= item.cost
+
+		newElem := s.coldList.PushBack(item)
+		s.elements[item.key] = newElem
+		if s.coldHand == nil {
+			s.coldHand = newElem
+		}
+		return
 	}
+}
 
-	return evicted
+// sweepHot is the hot-ring counterpart of demoteColdestHot, invoked from
+// evict when hot occupancy has crept past its target share: it gives the
+// hand's current item one more chance to prove it's still active before
+// demoting it, the same bit-clearing rule CLOCK applies to the cold ring.
+func (s *CacheShard) sweepHot() {
+	s.demoteColdestHot()
+}
+
+// removeFromRing detaches elem from ring, advancing *hand past it first
+// (wrapping to nil once the ring empties). The caller must already hold
+// the owning shard's mu.
+func (s *CacheShard) removeFromRing(ring *list.List, hand **list.Element, elem *list.Element) {
+	if *hand == elem {
+		next := nextOrFirst(elem, ring)
+		if next == elem {
+			next = nil
+		}
+		*hand = next
+	}
+	ring.Remove(elem)
+	if ring.Len() == 0 {
+		*hand = nil
+	}
 }
 
 // nextOrFirst returns the next element or circles back to the first
@@ -242,7 +731,8 @@ func nextOrFirst(e *list.Element, l *list.List) *list.Element {
 	return e.Next()
 }
 
-// Close stops all background goroutines
-func (c *ShardedCache) Close() {
+// Close stops all background goroutines, satisfying the Store interface.
+func (c *ShardedCache) Close() error {
 	close(c.stopChan)
+	return nil
 }
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(CachePersistenceModeEnv, persistenceModeSnapshot)
+	t.Setenv(CacheSnapshotDirEnv, dir)
+	ctx := context.Background()
+
+	c1 := NewCache()
+	if err := c1.Put(ctx, "a", "1", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c1.Put(ctx, "b", "2", time.Hour); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c1.SaveSnapshot(ctx); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	c1.Close()
+
+	c2 := NewCache()
+	defer c2.Close()
+
+	if val, ok, err := c2.Get(ctx, "a"); err != nil || !ok || val != "1" {
+		t.Fatalf("Get(a) after reload = (%q, %v, %v), want (1, true, nil)", val, ok, err)
+	}
+	if val, ok, err := c2.Get(ctx, "b"); err != nil || !ok || val != "2" {
+		t.Fatalf("Get(b) after reload = (%q, %v, %v), want (2, true, nil)", val, ok, err)
+	}
+	if ttl, ok := c2.TTL("b"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("TTL(b) after reload = (%v, %v), want a positive duration <= 1h", ttl, ok)
+	}
+}
+
+func TestAOFReplayRestoresLastWrite(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(CachePersistenceModeEnv, persistenceModeAOF)
+	t.Setenv(CacheAOFPathEnv, dir+"/cache.aof")
+	ctx := context.Background()
+
+	c1 := NewCache()
+	if err := c1.Put(ctx, "a", "1", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c1.Put(ctx, "a", "2", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c1.Put(ctx, "b", "stale", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c1.Delete(ctx, "b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	c1.aof.flushAndSync()
+	c1.Close()
+
+	c2 := NewCache()
+	defer c2.Close()
+
+	if val, ok, err := c2.Get(ctx, "a"); err != nil || !ok || val != "2" {
+		t.Fatalf("Get(a) after replay = (%q, %v, %v), want (2, true, nil)", val, ok, err)
+	}
+	if _, ok, err := c2.Get(ctx, "b"); err != nil || ok {
+		t.Fatalf("Get(b) after replay = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+}
+
+func TestAOFReplayPreservesExpireTTL(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(CachePersistenceModeEnv, persistenceModeAOF)
+	t.Setenv(CacheAOFPathEnv, dir+"/cache.aof")
+	ctx := context.Background()
+
+	c1 := NewCache()
+	if err := c1.Put(ctx, "k", "v", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if !c1.Expire("k", time.Hour) {
+		t.Fatal("Expire on an existing key returned false")
+	}
+	c1.aof.flushAndSync()
+	c1.Close()
+
+	c2 := NewCache()
+	defer c2.Close()
+
+	if ttl, ok := c2.TTL("k"); !ok || ttl == -1 || ttl > time.Hour {
+		t.Fatalf("TTL(k) after replay = (%v, %v), want a positive duration <= 1h, not permanent", ttl, ok)
+	}
+}
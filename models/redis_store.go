@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a remote L3 Store backed by a real Redis (or
+// Redis-compatible) server, for deployments that want a shared tier
+// beyond any single instance's disk.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the Redis server at addr. An empty addr falls
+// back to the standard local default, matching redisproto.DefaultAddr's
+// own fallback-to-default-port convention.
+func NewRedisStore(addr string) (*RedisStore, error) {
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
+	val, err := s.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return val, true, nil
+}
+
+func (s *RedisStore) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// TTL reports key's remaining time-to-live, satisfying TTLReader, by
+// deferring to Redis's own TTL command rather than tracking expiry
+// ourselves.
+func (s *RedisStore) TTL(key string) (time.Duration, bool) {
+	ttl, err := s.client.TTL(context.Background(), key).Result()
+	if err != nil {
+		return 0, false
+	}
+	// go-redis passes Redis's -2 (missing) / -1 (no expiry) replies through
+	// as raw Durations rather than scaling them to seconds.
+	if ttl == -2 || ttl == -2*time.Second {
+		return 0, false
+	}
+	if ttl == -1 || ttl == -1*time.Second {
+		return -1, true
+	}
+	return ttl, true
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the minimal contract a cache tier must satisfy to take part in a
+// ChainCache. ShardedCache (L1, in-memory) and the on-disk/remote adapters in
+// this package (BadgerStore, BoltStore, RedisStore) all implement it.
+type Store interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Put(ctx context.Context, key, value string, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+// TTLStore is an optional capability interface for tiers that can report and
+// mutate a key's remaining time-to-live. ShardedCache implements it, and
+// ChainCache forwards it to its first tier that does; callers that need EXPIRE/TTL semantics (e.g. redisproto) should
+// type-assert for it and fail gracefully when a tier doesn't support it.
+type TTLStore interface {
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, ttl time.Duration) bool
+	Persist(key string) bool
+}
+
+// Snapshotter is an optional capability interface for tiers that can persist
+// their state to durable storage on demand (e.g. on shutdown).
+type Snapshotter interface {
+	SaveSnapshot(ctx context.Context) error
+}
+
+// TTLReader is an optional capability interface for tiers that can report a
+// key's remaining time-to-live: -1 means "no expiry", and the bool is false
+// if the key isn't present. It's narrower than TTLStore (which also mutates
+// TTLs and only ShardedCache and the forwarding ChainCache implement) so that on-disk/remote tiers, which
+// all track an expiry internally but don't expose EXPIRE/PERSIST, can still
+// report it for ChainCache.backfill to propagate.
+type TTLReader interface {
+	TTL(key string) (time.Duration, bool)
+}
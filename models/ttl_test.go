@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPutTTLExpiresOnGet(t *testing.T) {
+	c := NewCache()
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Put(ctx, "k", "v", 10*time.Millisecond); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if val, ok, err := c.Get(ctx, "k"); err != nil || !ok || val != "v" {
+		t.Fatalf("Get before expiry = (%q, %v, %v), want (v, true, nil)", val, ok, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok, err := c.Get(ctx, "k"); err != nil || ok {
+		t.Fatalf("Get after expiry = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+}
+
+func TestReapShardRemovesExpiredKeys(t *testing.T) {
+	c := NewCache()
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Put(ctx, "expires-soon", "v", 5*time.Millisecond); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	shard := c.getShard("expires-soon")
+	c.reapShard(shard)
+
+	shard.mu.RLock()
+	_, stillPresent := shard.items["expires-soon"]
+	shard.mu.RUnlock()
+	if stillPresent {
+		t.Fatal("reapShard left an expired key in place")
+	}
+}
+
+func TestExpireAndPersist(t *testing.T) {
+	c := NewCache()
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Put(ctx, "k", "v", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if ttl, ok := c.TTL("k"); !ok || ttl != -1 {
+		t.Fatalf("TTL on a never-expiring key = (%v, %v), want (-1, true)", ttl, ok)
+	}
+
+	if !c.Expire("k", 50*time.Millisecond) {
+		t.Fatal("Expire on an existing key returned false")
+	}
+	if ttl, ok := c.TTL("k"); !ok || ttl <= 0 || ttl > 50*time.Millisecond {
+		t.Fatalf("TTL after Expire = (%v, %v), want a positive duration <= 50ms", ttl, ok)
+	}
+
+	if !c.Persist("k") {
+		t.Fatal("Persist on a key with a live TTL returned false")
+	}
+	if ttl, ok := c.TTL("k"); !ok || ttl != -1 {
+		t.Fatalf("TTL after Persist = (%v, %v), want (-1, true)", ttl, ok)
+	}
+	if c.Persist("k") {
+		t.Fatal("Persist on an already-permanent key returned true")
+	}
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"Key_Value_Cache_Ass/metrics"
+)
+
+const (
+	// WatchChannelBuffer bounds how far a subscriber can lag before its
+	// events start being dropped rather than blocking the mutation path.
+	WatchChannelBuffer = 32
+
+	// WatchRingSize is the number of recent events each shard retains for
+	// EventsSince catch-up after a client reconnects with ?from_rev=N.
+	WatchRingSize = 256
+)
+
+// EventType identifies what kind of mutation produced an Event.
+type EventType int
+
+const (
+	EventPut EventType = iota
+	EventDelete
+	EventEvict
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventPut:
+		return "put"
+	case EventDelete:
+		return "delete"
+	case EventEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON renders EventType as its lowercase name rather than an int,
+// matching the JSON-first style of the rest of the HTTP API.
+func (t EventType) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", t.String())), nil
+}
+
+// Event describes a single key mutation, delivered to watchers whose prefix
+// matches Key. Rev is a cache-wide monotonic counter assigned at the moment
+// of the mutation, used by clients to resume a watch with EventsSince.
+type Event struct {
+	Type  EventType `json:"type"`
+	Key   string    `json:"key"`
+	Value string    `json:"value,omitempty"`
+	Rev   uint64    `json:"rev"`
+}
+
+// Watcher is an optional capability interface for Store tiers that support
+// the key-change watch API. ShardedCache implements it, and ChainCache
+// forwards it to its first tier that does.
+type Watcher interface {
+	Watch(ctx context.Context, keyPrefix string) (<-chan Event, error)
+	EventsSince(keyPrefix string, fromRev uint64) []Event
+}
+
+// watcher is one live subscription, shared by every shard it's registered
+// against (a key prefix isn't shard-local, since shards are chosen by key
+// hash, so a watch has to fan in from all of them).
+type watcher struct {
+	ch      chan Event
+	prefix  string
+	dropped int32 // atomic; set once, after which the channel is closed
+}
+
+func (w *watcher) matches(key string) bool {
+	return strings.HasPrefix(key, w.prefix)
+}
+
+// send delivers ev to w non-blockingly. If w's channel is already full, it
+// is dropped permanently: marked, closed, and counted, rather than risking
+// a slow consumer stalling every shard's mutation path.
+func (w *watcher) send(ev Event) {
+	if atomic.LoadInt32(&w.dropped) == 1 {
+		return
+	}
+	select {
+	case w.ch <- ev:
+	default:
+		if atomic.CompareAndSwapInt32(&w.dropped, 0, 1) {
+			close(w.ch)
+			metrics.DroppedEventsTotal.Inc()
+		}
+	}
+}
+
+// nextRev returns the next cache-wide monotonic revision number.
+func (c *ShardedCache) nextRev() uint64 {
+	return uint64(atomic.AddInt64(&c.rev, 1))
+}
+
+// Watch subscribes to every Put/Delete/Evict affecting a key with the given
+// prefix (an empty prefix matches every key), satisfying the Watcher
+// capability interface. The returned channel is closed once ctx is done or
+// the subscriber falls too far behind to keep up.
+func (c *ShardedCache) Watch(ctx context.Context, keyPrefix string) (<-chan Event, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	w := &watcher{ch: make(chan Event, WatchChannelBuffer), prefix: keyPrefix}
+	for _, shard := range c.shards {
+		shard.addWatcher(w)
+	}
+
+	go func() {
+		<-ctx.Done()
+		for _, shard := range c.shards {
+			shard.removeWatcher(w)
+		}
+		if atomic.CompareAndSwapInt32(&w.dropped, 0, 1) {
+			close(w.ch)
+		}
+	}()
+
+	return w.ch, nil
+}
+
+// EventsSince gathers every ring-buffered event across all shards matching
+// keyPrefix with Rev > fromRev, for a client resuming a watch after a
+// disconnect. Coverage is best-effort: events older than a shard's
+// WatchRingSize-event window are already gone.
+func (c *ShardedCache) EventsSince(keyPrefix string, fromRev uint64) []Event {
+	var events []Event
+	for _, shard := range c.shards {
+		events = append(events, shard.eventsSince(keyPrefix, fromRev)...)
+	}
+	sortEventsByRev(events)
+	return events
+}
+
+// sortEventsByRev does a plain insertion sort: shard ring buffers are each
+// individually ordered, and there are only shardCount of them to merge, so
+// this avoids pulling in sort for what's a small, mostly-sorted merge.
+func sortEventsByRev(events []Event) {
+	for i := 1; i < len(events); i++ {
+		for j := i; j > 0 && events[j-1].Rev > events[j].Rev; j-- {
+			events[j-1], events[j] = events[j], events[j-1]
+		}
+	}
+}
+
+// addWatcher registers w against the shard's subscriber list.
+func (s *CacheShard) addWatcher(w *watcher) {
+	s.subMu.Lock()
+	s.subs = append(s.subs, w)
+	s.subMu.Unlock()
+}
+
+// removeWatcher unregisters w from the shard's subscriber list.
+func (s *CacheShard) removeWatcher(w *watcher) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for i, sub := range s.subs {
+		if sub == w {
+			s.subs = append(s.subs[:i], s.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish records ev in the shard's catch-up ring buffer and fans it out to
+// every watcher whose prefix matches. The subscriber list is snapshotted
+// under subMu and then released before sending, so a slow or blocked
+// watcher can never hold up the shard's mutation path.
+func (s *CacheShard) publish(ev Event) {
+	s.subMu.Lock()
+	if len(s.ring) < WatchRingSize {
+		s.ring = append(s.ring, ev)
+	} else {
+		s.ring[s.ringPos] = ev
+		s.ringPos = (s.ringPos + 1) % WatchRingSize
+	}
+	subs := append([]*watcher(nil), s.subs...)
+	s.subMu.Unlock()
+
+	for _, w := range subs {
+		if w.matches(ev.Key) {
+			w.send(ev)
+		}
+	}
+}
+
+// eventsSince returns this shard's ring-buffered events matching prefix
+// with Rev > fromRev, oldest first.
+func (s *CacheShard) eventsSince(prefix string, fromRev uint64) []Event {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+
+	var matched []Event
+	// s.ring is stored oldest-to-newest except for the WatchRingSize+
+	// wraparound case, where ringPos marks the oldest entry.
+	n := len(s.ring)
+	for i := 0; i < n; i++ {
+		idx := i
+		if n == WatchRingSize {
+			idx = (s.ringPos + i) % WatchRingSize
+		}
+		ev := s.ring[idx]
+		if ev.Rev > fromRev && strings.HasPrefix(ev.Key, prefix) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched
+}
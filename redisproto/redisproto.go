@@ -0,0 +1,422 @@
+// Package redisproto implements a minimal RESP2 front-end so that redis-cli
+// and other Redis client libraries can talk to the cache directly, alongside
+// the existing HTTP JSON API. RESP3 is not negotiated: HELLO is answered as
+// an unknown command and every reply uses RESP2 types. It dispatches commands against the
+// same models.Store instance used by the HTTP controllers, so both
+// frontends share state and eviction.
+package redisproto
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"Key_Value_Cache_Ass/models"
+)
+
+// DefaultAddr is the address the RESP listener binds to by default.
+const DefaultAddr = ":6379"
+
+// requestTimeout bounds how long a single command may take against the
+// cache, mirroring the timeout the HTTP controllers apply per request.
+const requestTimeout = 2 * time.Second
+
+// maxMultiBulkLen and maxBulkLen bound the array length and per-element
+// byte length readRequest will allocate for, mirroring real Redis's
+// proto-max-bulk-len default and persistence.go's maxRecordSize: an
+// unauthenticated client on this port could otherwise send a single
+// oversized length prefix (e.g. "$2000000000\r\n") and force a multi-GB
+// allocation in the same process serving the HTTP API.
+const (
+	maxMultiBulkLen = 1024 * 1024
+	maxBulkLen      = 512 << 20 // 512 MiB
+)
+
+// Start launches the RESP server in a background goroutine, accepting
+// connections on addr and dispatching commands against cache. It returns
+// immediately; listener errors are logged rather than returned, matching
+// the fire-and-forget style of the existing HTTP server startup.
+func Start(addr string, cache models.Store) {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	go func() {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Printf("redisproto: failed to listen on %s: %v", addr, err)
+			return
+		}
+
+		log.Printf("RESP server listening on %s", addr)
+
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				log.Printf("redisproto: accept error: %v", err)
+				continue
+			}
+			go handleConn(conn, cache)
+		}
+	}()
+}
+
+func handleConn(conn net.Conn, cache models.Store) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	for {
+		args, err := readRequest(reader)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if cmd == "SUBSCRIBE" || cmd == "PSUBSCRIBE" {
+			subscribeLoop(conn, reader, writer, cache, cmd, args)
+			return
+		}
+
+		if !dispatch(writer, cache, args) {
+			writer.Flush()
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// subscribeLoop takes over conn on SUBSCRIBE/PSUBSCRIBE, streaming matching
+// key-change events as RESP push messages until the client disconnects or
+// sends UNSUBSCRIBE/PUNSUBSCRIBE/QUIT. Only a single channel/pattern is
+// supported per command, matching the simplified prefix-only semantics of
+// the underlying models.Watcher.
+func subscribeLoop(conn net.Conn, reader *bufio.Reader, writer *bufio.Writer, cache models.Store, cmd string, args []string) {
+	watcher, ok := cache.(models.Watcher)
+	if !ok {
+		writeError(writer, "ERR "+cmd+" is not supported by the configured cache tier")
+		writer.Flush()
+		return
+	}
+	if len(args) != 2 {
+		writeError(writer, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+		writer.Flush()
+		return
+	}
+
+	channel := args[1]
+	// PSUBSCRIBE patterns are matched as a prefix only: a trailing '*' (the
+	// common "foo*" glob) is stripped, anything else is taken literally.
+	prefix := channel
+	if cmd == "PSUBSCRIBE" {
+		prefix = strings.TrimSuffix(channel, "*")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := watcher.Watch(ctx, prefix)
+	if err != nil {
+		writeError(writer, fmt.Sprintf("ERR %v", err))
+		writer.Flush()
+		return
+	}
+
+	writeSubscribeAck(writer, strings.ToLower(cmd), channel)
+	if err := writer.Flush(); err != nil {
+		return
+	}
+
+	// A background reader keeps consuming client input so UNSUBSCRIBE, QUIT,
+	// or a dropped connection can end the stream; its result is handed back
+	// over done rather than touched directly from this goroutine.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			args, err := readRequest(reader)
+			if err != nil {
+				return
+			}
+			if len(args) == 0 {
+				continue
+			}
+			switch strings.ToUpper(args[0]) {
+			case "UNSUBSCRIBE", "PUNSUBSCRIBE", "QUIT":
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			writeMessage(writer, channel, ev)
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// writeSubscribeAck writes the RESP reply a client expects right after
+// issuing SUBSCRIBE/PSUBSCRIBE: a 3-element array of (kind, channel, count).
+// This server only ever tracks one subscription per connection, so count is
+// always 1.
+func writeSubscribeAck(w *bufio.Writer, kind, channel string) {
+	writeArrayHeader(w, 3)
+	writeBulkString(w, kind)
+	writeBulkString(w, channel)
+	writeInteger(w, 1)
+}
+
+// writeMessage writes ev as the RESP push message format real Redis clients
+// expect from an active subscription: a 3-element array of ("message",
+// channel, payload).
+func writeMessage(w *bufio.Writer, channel string, ev models.Event) {
+	writeArrayHeader(w, 3)
+	writeBulkString(w, "message")
+	writeBulkString(w, channel)
+	writeBulkString(w, fmt.Sprintf("%s %s %s %d", ev.Type, ev.Key, ev.Value, ev.Rev))
+}
+
+// readRequest reads one client request, transparently handling both the
+// RESP multi-bulk array form (used by real clients) and the plain inline
+// form (used by e.g. `nc` or `telnet`).
+func readRequest(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count < 0 || count > maxMultiBulkLen {
+		return nil, fmt.Errorf("redisproto: invalid multi-bulk length")
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		header, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("redisproto: expected bulk string header")
+		}
+
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, fmt.Errorf("redisproto: invalid bulk length")
+		}
+
+		buf := make([]byte, size+2) // payload + trailing \r\n
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+// readLine reads a single \r\n-terminated line and strips the terminator.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// dispatch executes a single command against cache and writes its RESP
+// reply to writer. It returns false when the connection should be closed
+// (i.e. on QUIT).
+func dispatch(w *bufio.Writer, cache models.Store, args []string) bool {
+	cmd := strings.ToUpper(args[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	switch cmd {
+	case "PING":
+		if len(args) > 1 {
+			writeBulkString(w, args[1])
+		} else {
+			writeSimpleString(w, "PONG")
+		}
+
+	case "SET":
+		if len(args) < 3 {
+			writeError(w, "ERR wrong number of arguments for 'set' command")
+			break
+		}
+		if err := cache.Put(ctx, args[1], args[2], 0); err != nil {
+			writeError(w, fmt.Sprintf("ERR %v", err))
+			break
+		}
+		writeSimpleString(w, "OK")
+
+	case "GET":
+		if len(args) != 2 {
+			writeError(w, "ERR wrong number of arguments for 'get' command")
+			break
+		}
+		val, ok, err := cache.Get(ctx, args[1])
+		if err != nil {
+			writeError(w, fmt.Sprintf("ERR %v", err))
+			break
+		}
+		if ok {
+			writeBulkString(w, val)
+		} else {
+			writeNullBulk(w)
+		}
+
+	case "DEL":
+		if len(args) < 2 {
+			writeError(w, "ERR wrong number of arguments for 'del' command")
+			break
+		}
+		deleted := 0
+		for _, key := range args[1:] {
+			// Delete doesn't report whether the key existed, so check
+			// first to keep DEL's reply count accurate.
+			if _, ok, err := cache.Get(ctx, key); err == nil && ok {
+				if cache.Delete(ctx, key) == nil {
+					deleted++
+				}
+			}
+		}
+		writeInteger(w, int64(deleted))
+
+	case "EXISTS":
+		if len(args) < 2 {
+			writeError(w, "ERR wrong number of arguments for 'exists' command")
+			break
+		}
+		found := 0
+		for _, key := range args[1:] {
+			if _, ok, err := cache.Get(ctx, key); err == nil && ok {
+				found++
+			}
+		}
+		writeInteger(w, int64(found))
+
+	case "EXPIRE":
+		if len(args) != 3 {
+			writeError(w, "ERR wrong number of arguments for 'expire' command")
+			break
+		}
+		seconds, err := strconv.Atoi(args[2])
+		if err != nil {
+			writeError(w, "ERR value is not an integer or out of range")
+			break
+		}
+		ttlCache, ok := cache.(models.TTLStore)
+		if !ok {
+			writeError(w, "ERR EXPIRE is not supported by the configured cache tier")
+			break
+		}
+		if ttlCache.Expire(args[1], time.Duration(seconds)*time.Second) {
+			writeInteger(w, 1)
+		} else {
+			writeInteger(w, 0)
+		}
+
+	case "TTL":
+		if len(args) != 2 {
+			writeError(w, "ERR wrong number of arguments for 'ttl' command")
+			break
+		}
+		ttlCache, ok := cache.(models.TTLStore)
+		if !ok {
+			writeError(w, "ERR TTL is not supported by the configured cache tier")
+			break
+		}
+		ttl, ok := ttlCache.TTL(args[1])
+		switch {
+		case !ok:
+			writeInteger(w, -2)
+		case ttl < 0:
+			writeInteger(w, -1)
+		default:
+			writeInteger(w, int64(ttl/time.Second))
+		}
+
+	case "SELECT":
+		// Single logical database; only index 0 is valid.
+		if len(args) == 2 && args[1] == "0" {
+			writeSimpleString(w, "OK")
+		} else {
+			writeError(w, "ERR DB index is out of range")
+		}
+
+	case "COMMAND":
+		// Real clients issue COMMAND DOCS/COUNT on connect; an empty
+		// array keeps them happy without implementing a full catalog.
+		writeArrayHeader(w, 0)
+
+	case "INFO":
+		writeBulkString(w, "# Server\r\nredis_version:7.0.0\r\nredis_mode:standalone\r\n")
+
+	case "CLIENT":
+		writeSimpleString(w, "OK")
+
+	case "QUIT":
+		writeSimpleString(w, "OK")
+		return false
+
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+
+	return true
+}
+
+func writeSimpleString(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "+%s\r\n", s)
+}
+
+func writeError(w *bufio.Writer, msg string) {
+	fmt.Fprintf(w, "-%s\r\n", msg)
+}
+
+func writeInteger(w *bufio.Writer, n int64) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func writeBulkString(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeNullBulk(w *bufio.Writer) {
+	w.WriteString("$-1\r\n")
+}
+
+func writeArrayHeader(w *bufio.Writer, n int) {
+	fmt.Fprintf(w, "*%d\r\n", n)
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"Key_Value_Cache_Ass/controllers"
+	"Key_Value_Cache_Ass/metrics"
+	"Key_Value_Cache_Ass/redisproto"
 )
 
 // RegisterRoutes registers all endpoints with optimized server settings.
@@ -16,10 +18,16 @@ func RegisterRoutes() *http.Server {
 		fmt.Print("Received request to /")
 		w.Write([]byte("yes"))
 	})
-	http.HandleFunc("/put", controllers.PutCache)
-	http.HandleFunc("/get", controllers.GetCache)
-	
+	http.HandleFunc("/put", metrics.Wrap("/put", controllers.PutCache))
+	http.HandleFunc("/get", metrics.Wrap("/get", controllers.GetCache))
+	http.HandleFunc("/watch", controllers.WatchCache)
+	http.Handle("/metrics", metrics.Handler())
+
 	fmt.Println("Routes registered")
+
+	// Start the RESP protocol frontend alongside HTTP, sharing CacheInstance
+	// so redis-cli and other Redis client libraries can talk to the same cache.
+	redisproto.Start(redisproto.DefaultAddr, controllers.CacheInstance)
 	// Create optimized server
 	server := &http.Server{
 		Addr:         ":7171",